@@ -0,0 +1,49 @@
+package api
+
+import (
+	"context"
+
+	"connectrpc.com/connect"
+
+	typesv1alpha1 "github.com/akuity/kargo/internal/api/types/v1alpha1"
+	svcv1alpha1 "github.com/akuity/kargo/pkg/api/service/v1alpha1"
+)
+
+// CreateStages is a batch sibling of CreateStage. It server-side applies
+// every Stage in req.Msg.GetStages() and returns one result per Stage, in
+// the same order they were given, so that a caller reconciling a whole
+// environment (e.g. dev, then qa, then prod) in one call can see which
+// Stages applied cleanly and which didn't, instead of the whole call
+// aborting on the first failure.
+func (s *server) CreateStages(
+	ctx context.Context,
+	req *connect.Request[svcv1alpha1.CreateStagesRequest],
+) (*connect.Response[svcv1alpha1.CreateStagesResponse], error) {
+	stageReqs := req.Msg.GetStages()
+	results := make([]*svcv1alpha1.CreateStageResult, len(stageReqs))
+	for i, stageReq := range stageReqs {
+		stage, err := stageFromCreateRequest(stageReq)
+		if err == nil {
+			if err = s.validateProject(ctx, stage.GetNamespace()); err == nil {
+				dryRun := req.Msg.GetDryRun() || stageReq.GetDryRun()
+				err = applyStage(ctx, s.client, &stage, stageReq.GetFieldManager(), dryRun)
+			}
+		}
+		if err != nil {
+			results[i] = &svcv1alpha1.CreateStageResult{
+				Result: &svcv1alpha1.CreateStageResult_Error{
+					Error: err.Error(),
+				},
+			}
+			continue
+		}
+		results[i] = &svcv1alpha1.CreateStageResult{
+			Result: &svcv1alpha1.CreateStageResult_Stage{
+				Stage: typesv1alpha1.ToStageProto(stage),
+			},
+		}
+	}
+	return connect.NewResponse(&svcv1alpha1.CreateStagesResponse{
+		Results: results,
+	}), nil
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"context"
+
+	"connectrpc.com/connect"
+	"github.com/pkg/errors"
+	kubeerr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	kargoapi "github.com/akuity/kargo/api/v1alpha1"
+	typesv1alpha1 "github.com/akuity/kargo/internal/api/types/v1alpha1"
+	svcv1alpha1 "github.com/akuity/kargo/pkg/api/service/v1alpha1"
+)
+
+// defaultStageFieldManager is the server-side apply field manager used for
+// Stages created through the API when the caller does not specify one.
+const defaultStageFieldManager = "kargo-api"
+
+// stageFromCreateRequest builds the kargoapi.Stage described by req. It does
+// not talk to the cluster; callers are responsible for validating and
+// applying the result.
+func stageFromCreateRequest(req *svcv1alpha1.CreateStageRequest) (kargoapi.Stage, error) {
+	var stage kargoapi.Stage
+	switch {
+	case req.GetYaml() != "":
+		if err := yaml.Unmarshal([]byte(req.GetYaml()), &stage); err != nil {
+			return stage, connect.NewError(connect.CodeInvalidArgument, errors.Wrap(err, "invalid yaml"))
+		}
+	case req.GetTyped() != nil:
+		if req.GetTyped().GetProject() == "" {
+			return stage, connect.NewError(connect.CodeInvalidArgument, errors.New("project should not be empty"))
+		}
+		if req.GetTyped().GetName() == "" {
+			return stage, connect.NewError(connect.CodeInvalidArgument, errors.New("name should not be empty"))
+		}
+		stage = kargoapi.Stage{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: req.GetTyped().GetProject(),
+				Name:      req.GetTyped().GetName(),
+			},
+			Spec: typesv1alpha1.FromStageSpecProto(req.GetTyped().GetSpec()),
+		}
+	default:
+		return stage, connect.NewError(connect.CodeInvalidArgument, errors.New("stage should not be empty"))
+	}
+	return stage, nil
+}
+
+// applyStage server-side applies stage, using fieldManager (or
+// defaultStageFieldManager, if empty) as the field owner. If dryRun is true,
+// the apply is run with all mutations suppressed, so that callers can
+// preview ownership conflicts and admission errors without touching the
+// cluster. A field-ownership conflict is surfaced as a connect.CodeAborted
+// error; any other failure is returned wrapped, without a connect error code.
+func applyStage(
+	ctx context.Context,
+	c client.Client,
+	stage *kargoapi.Stage,
+	fieldManager string,
+	dryRun bool,
+) error {
+	if fieldManager == "" {
+		fieldManager = defaultStageFieldManager
+	}
+	stage.APIVersion = kargoapi.GroupVersion.String()
+	stage.Kind = "Stage"
+	patchOpts := []client.PatchOption{
+		client.FieldOwner(fieldManager),
+	}
+	if dryRun {
+		patchOpts = append(patchOpts, client.DryRunAll)
+	}
+	if err := c.Patch(ctx, stage, client.Apply, patchOpts...); err != nil {
+		if kubeerr.IsConflict(err) {
+			return connect.NewError(connect.CodeAborted, err)
+		}
+		return errors.Wrap(err, "apply stage")
+	}
+	return nil
+}
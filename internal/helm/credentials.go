@@ -0,0 +1,40 @@
+package helm
+
+// Credentials represents the credentials that may be required to access a
+// private Helm chart repository, whether a classic (HTTP/S) repository or a
+// repository within an OCI registry. Only Username and Password are used for
+// repositories within an OCI registry; the remaining fields apply to classic
+// repositories only.
+type Credentials struct {
+	// Username is the username to use when authenticating to the chart
+	// repository.
+	Username string
+	// Password is the password to use when authenticating to the chart
+	// repository.
+	Password string
+	// BearerToken, if set, is sent as an HTTP Authorization: Bearer header
+	// instead of HTTP Basic auth. It takes precedence over Username and
+	// Password.
+	BearerToken string
+	// CAFile is the path to a PEM-encoded CA certificate bundle used to
+	// verify the chart repository's TLS certificate. If empty, the host's
+	// default CA bundle is used.
+	CAFile string
+	// CertFile is the path to a PEM-encoded client certificate presented for
+	// mTLS. CertFile and KeyFile must be set together.
+	CertFile string
+	// KeyFile is the path to the PEM-encoded private key corresponding to
+	// CertFile. CertFile and KeyFile must be set together.
+	KeyFile string
+	// InsecureSkipTLSVerify disables verification of the chart repository's
+	// TLS certificate. This should only ever be used for testing.
+	InsecureSkipTLSVerify bool
+	// PassCredentialsAll indicates whether these credentials are also sent
+	// when downloading a chart file from a host other than repoURL's. When
+	// false, downloads send them only to repoURL's own host. It does not
+	// affect redirects, which follow net/http's default handling, or
+	// UpdateChartDependencies, which passes these credentials to every
+	// dependency repository. This mirrors Helm's `--pass-credentials` flag
+	// and defaults to false for safety.
+	PassCredentialsAll bool
+}
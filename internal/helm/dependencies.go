@@ -0,0 +1,302 @@
+package helm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// chartDependency describes a single entry in a chart's `dependencies` list,
+// as declared in Chart.yaml (or, for legacy v1 charts, requirements.yaml).
+// Version is a semver constraint (e.g. "~1.2" or ">=1.0,<2.0"), not
+// necessarily an exact version.
+type chartDependency struct {
+	Name       string `yaml:"name"`
+	Version    string `yaml:"version"`
+	Repository string `yaml:"repository"`
+}
+
+// chartDependencies is the subset of a Chart.yaml (or requirements.yaml)
+// document that UpdateChartDependencies cares about.
+type chartDependencies struct {
+	Dependencies []chartDependency `yaml:"dependencies,omitempty"`
+}
+
+// chartLock is the structure of a chart's Chart.lock file.
+type chartLock struct {
+	Dependencies []chartDependency `yaml:"dependencies"`
+	Digest       string            `yaml:"digest"`
+	Generated    string            `yaml:"generated"`
+}
+
+// UpdateChartDependencies resolves the dependencies declared in the
+// Chart.yaml (or, for legacy v1 charts, requirements.yaml) found at
+// chartPath, downloads each into chartPath's charts/ subdirectory, and
+// writes the resolved, concrete versions to a Chart.lock file alongside it.
+// Each dependency's version field is treated as a semver constraint and is
+// resolved against its declared repository using the same logic as
+// SelectChartVersion, reusing creds for any dependency repository that
+// requires authentication. A dependency with no repository is assumed to
+// already be vendored under charts/ and is left untouched; a dependency
+// whose repository begins with "file://" is treated as a path (relative to
+// chartPath) to a local subchart and is copied in as-is, per the same rules
+// `helm dependency update` follows for local dependencies. Before the
+// resolved set is written, any archive under charts/ named after a
+// dependency that has a repository (<name>-*.tgz) is removed, so that a
+// dependency which has since moved to a new version doesn't leave its old
+// archive behind alongside the new one.
+// This is an in-process replacement for shelling out to `helm dependency
+// update`, removing the `helm` binary as a runtime dependency of the Kargo
+// controller image.
+func UpdateChartDependencies(
+	ctx context.Context,
+	chartPath string,
+	creds *Credentials,
+) error {
+	deps, err := readChartDependencies(chartPath)
+	if err != nil {
+		return errors.Wrapf(err, "error reading dependencies of chart at %q", chartPath)
+	}
+	if len(deps) == 0 {
+		return nil
+	}
+
+	chartsDir := filepath.Join(chartPath, "charts")
+	if err = os.MkdirAll(chartsDir, 0o755); err != nil {
+		return errors.Wrapf(err, "error creating charts directory %q", chartsDir)
+	}
+	for _, dep := range deps {
+		if dep.Repository == "" {
+			continue
+		}
+		stale, _ := filepath.Glob(filepath.Join(chartsDir, dep.Name+"-*.tgz"))
+		for _, archive := range stale {
+			if err = os.Remove(archive); err != nil {
+				return errors.Wrapf(err, "error removing stale archive %q", archive)
+			}
+		}
+	}
+
+	resolved := make([]chartDependency, len(deps))
+	for i, dep := range deps {
+		switch {
+		case dep.Repository == "":
+			// No repository means the dependency is expected to already be
+			// vendored under charts/, which was left in place above, so there
+			// is nothing more to do here: the chart is simply declared, as-is,
+			// in the lock.
+			resolved[i] = dep
+			continue
+		case strings.HasPrefix(dep.Repository, "file://"):
+			localPath := filepath.Join(chartPath, strings.TrimPrefix(dep.Repository, "file://"))
+			version, err := readLocalChartVersion(localPath)
+			if err != nil {
+				return errors.Wrapf(err, "error reading local dependency %q at %q", dep.Name, localPath)
+			}
+			if err = copyDir(localPath, filepath.Join(chartsDir, dep.Name)); err != nil {
+				return errors.Wrapf(err, "error copying local dependency %q into %q", dep.Name, chartsDir)
+			}
+			resolved[i] = chartDependency{Name: dep.Name, Version: version, Repository: dep.Repository}
+			continue
+		}
+
+		version, err := SelectChartVersion(
+			ctx,
+			dep.Repository,
+			dep.Name,
+			dep.Version,
+			creds,
+			OCIListOptions{},
+			nil,
+		)
+		if err != nil {
+			return errors.Wrapf(
+				err,
+				"error resolving constraint %q for dependency %q",
+				dep.Version,
+				dep.Name,
+			)
+		}
+		if version == "" {
+			return errors.Errorf(
+				"no version of dependency %q satisfies constraint %q",
+				dep.Name,
+				dep.Version,
+			)
+		}
+
+		archivePath, err := DownloadChart(
+			ctx,
+			dep.Repository,
+			dep.Name,
+			version,
+			creds,
+			DownloadChartOptions{},
+		)
+		if err != nil {
+			return errors.Wrapf(
+				err,
+				"error downloading dependency %q version %q",
+				dep.Name,
+				version,
+			)
+		}
+		if err = copyFile(
+			archivePath,
+			filepath.Join(chartsDir, filepath.Base(archivePath)),
+		); err != nil {
+			return errors.Wrapf(
+				err,
+				"error copying dependency %q into %q",
+				dep.Name,
+				chartsDir,
+			)
+		}
+
+		resolved[i] = chartDependency{
+			Name:       dep.Name,
+			Version:    version,
+			Repository: dep.Repository,
+		}
+	}
+
+	digest, err := digestDependencies(deps)
+	if err != nil {
+		return errors.Wrap(err, "error computing dependencies digest")
+	}
+	lockBytes, err := yaml.Marshal(chartLock{
+		Dependencies: resolved,
+		Digest:       digest,
+		Generated:    time.Now().UTC().Format(time.RFC3339),
+	})
+	if err != nil {
+		return errors.Wrap(err, "error marshaling Chart.lock")
+	}
+	lockPath := filepath.Join(chartPath, "Chart.lock")
+	if err = os.WriteFile(lockPath, lockBytes, 0o644); err != nil {
+		return errors.Wrapf(err, "error writing %q", lockPath)
+	}
+	return nil
+}
+
+// readChartDependencies returns the dependencies declared by the chart at
+// chartPath, preferring the `dependencies` field of Chart.yaml and falling
+// back to a sibling requirements.yaml for legacy v1 charts that declare
+// dependencies separately. A chart with no dependencies returns a nil slice
+// and no error.
+func readChartDependencies(chartPath string) ([]chartDependency, error) {
+	chart, err := readChartDependenciesFile(filepath.Join(chartPath, "Chart.yaml"))
+	if err != nil {
+		return nil, err
+	}
+	if len(chart.Dependencies) > 0 {
+		return chart.Dependencies, nil
+	}
+	reqs, err := readChartDependenciesFile(filepath.Join(chartPath, "requirements.yaml"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return reqs.Dependencies, nil
+}
+
+// readChartDependenciesFile reads and parses the YAML file at path. The
+// returned error wraps the underlying os.ReadFile error, except when that
+// error indicates the file does not exist, in which case it is returned
+// unwrapped so that callers can still recognize it with os.IsNotExist.
+func readChartDependenciesFile(path string) (chartDependencies, error) {
+	deps := chartDependencies{}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return deps, err
+	}
+	if err != nil {
+		return deps, errors.Wrapf(err, "error reading %q", path)
+	}
+	if err = yaml.Unmarshal(data, &deps); err != nil {
+		return deps, errors.Wrapf(err, "error unmarshaling %q", path)
+	}
+	return deps, nil
+}
+
+// digestDependencies returns a "sha256:"-prefixed digest of deps, stable
+// under reordering, for recording in Chart.lock.
+func digestDependencies(deps []chartDependency) (string, error) {
+	sorted := make([]chartDependency, len(deps))
+	copy(sorted, deps)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	data, err := json.Marshal(sorted)
+	if err != nil {
+		return "", errors.Wrap(err, "error marshaling dependencies")
+	}
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:]), nil
+}
+
+// copyFile copies the file at src to dest, creating or truncating dest as
+// needed.
+func copyFile(src, dest string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return errors.Wrapf(err, "error opening %q", src)
+	}
+	defer in.Close()
+	out, err := os.Create(dest)
+	if err != nil {
+		return errors.Wrapf(err, "error creating %q", dest)
+	}
+	defer out.Close()
+	if _, err = io.Copy(out, in); err != nil {
+		return errors.Wrapf(err, "error copying %q to %q", src, dest)
+	}
+	return nil
+}
+
+// readLocalChartVersion reads the version field from the Chart.yaml of the
+// local chart directory at path.
+func readLocalChartVersion(path string) (string, error) {
+	chartYAMLPath := filepath.Join(path, "Chart.yaml")
+	data, err := os.ReadFile(chartYAMLPath)
+	if err != nil {
+		return "", errors.Wrapf(err, "error reading %q", chartYAMLPath)
+	}
+	chart := struct {
+		Version string `yaml:"version"`
+	}{}
+	if err = yaml.Unmarshal(data, &chart); err != nil {
+		return "", errors.Wrapf(err, "error unmarshaling %q", chartYAMLPath)
+	}
+	return chart.Version, nil
+}
+
+// copyDir recursively copies the directory tree rooted at src to dest,
+// creating dest if it does not already exist.
+func copyDir(src, dest string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return errors.Wrapf(err, "error relativizing %q to %q", path, src)
+		}
+		destPath := filepath.Join(dest, rel)
+		if d.IsDir() {
+			return os.MkdirAll(destPath, 0o755)
+		}
+		return copyFile(path, destPath)
+	})
+}
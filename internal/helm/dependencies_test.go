@@ -0,0 +1,88 @@
+package helm
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadChartDependencies(t *testing.T) {
+	t.Run("no dependencies and no requirements.yaml", func(t *testing.T) {
+		chartPath := t.TempDir()
+		writeFile(t, filepath.Join(chartPath, "Chart.yaml"), "name: mychart\nversion: 1.0.0\n")
+
+		deps, err := readChartDependencies(chartPath)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if len(deps) != 0 {
+			t.Fatalf("expected no dependencies, got %v", deps)
+		}
+	})
+
+	t.Run("dependencies declared inline in Chart.yaml", func(t *testing.T) {
+		chartPath := t.TempDir()
+		writeFile(t, filepath.Join(chartPath, "Chart.yaml"), `
+name: mychart
+version: 1.0.0
+dependencies:
+  - name: subchart
+    version: "~1.2"
+    repository: https://example.com/charts
+`)
+
+		deps, err := readChartDependencies(chartPath)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if len(deps) != 1 || deps[0].Name != "subchart" {
+			t.Fatalf("unexpected dependencies: %v", deps)
+		}
+	})
+
+	t.Run("dependencies declared in legacy requirements.yaml", func(t *testing.T) {
+		chartPath := t.TempDir()
+		writeFile(t, filepath.Join(chartPath, "Chart.yaml"), "name: mychart\nversion: 1.0.0\n")
+		writeFile(t, filepath.Join(chartPath, "requirements.yaml"), `
+dependencies:
+  - name: subchart
+    version: ">=1.0,<2.0"
+    repository: https://example.com/charts
+`)
+
+		deps, err := readChartDependencies(chartPath)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if len(deps) != 1 || deps[0].Name != "subchart" {
+			t.Fatalf("unexpected dependencies: %v", deps)
+		}
+	})
+}
+
+func TestDigestDependencies(t *testing.T) {
+	a := []chartDependency{
+		{Name: "a", Version: "1.0.0", Repository: "https://example.com/charts"},
+		{Name: "b", Version: "2.0.0", Repository: "https://example.com/charts"},
+	}
+	b := []chartDependency{a[1], a[0]}
+
+	digestA, err := digestDependencies(a)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	digestB, err := digestDependencies(b)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if digestA != digestB {
+		t.Fatalf("expected digest to be stable under reordering: %q != %q", digestA, digestB)
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("error writing %q: %s", path, err)
+	}
+}
@@ -2,10 +2,13 @@ package helm
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
-	"os/exec"
+	"net/url"
+	"os"
+	"path/filepath"
 	"sort"
 	"strings"
 
@@ -15,8 +18,6 @@ import (
 	"oras.land/oras-go/pkg/registry"
 	"oras.land/oras-go/pkg/registry/remote"
 	"oras.land/oras-go/pkg/registry/remote/auth"
-
-	libExec "github.com/akuity/kargo/internal/exec"
 )
 
 // SelectChartVersion connects to the Helm chart repository specified by
@@ -32,23 +33,30 @@ import (
 // semantically greatest version satisfying that constraint will be returned. If
 // no version satisfies the constraint, the empty string is returned. Provided
 // credentials may be nil for public repositories, but must be non-nil for
-// private repositories.
+// private repositories. ociOpts is only consulted when repoURL points to an
+// OCI registry; see OCIListOptions for details. cache, if non-nil, is
+// consulted and updated when repoURL points to a classic repository, so
+// that repeated calls against an unchanged index.yaml can be served from
+// cache instead of re-downloading and re-parsing it; it is ignored for OCI
+// repositories.
 func SelectChartVersion(
 	ctx context.Context,
 	repoURL string,
 	chart string,
 	semverConstraint string,
 	creds *Credentials,
+	ociOpts OCIListOptions,
+	cache IndexCache,
 ) (string, error) {
 	var versions []string
 	var err error
 	if strings.HasPrefix(repoURL, "http://") ||
 		strings.HasPrefix(repoURL, "https://") {
 		versions, err =
-			getChartVersionsFromClassicRepo(repoURL, chart, creds)
+			getChartVersionsFromClassicRepo(repoURL, chart, creds, cache)
 	} else if strings.HasPrefix(repoURL, "oci://") {
 		versions, err =
-			getChartVersionsFromOCIRepo(ctx, repoURL, creds)
+			getChartVersionsFromOCIRepo(ctx, repoURL, creds, ociOpts)
 	} else {
 		return "", errors.Errorf("repository URL %q is invalid", repoURL)
 	}
@@ -69,15 +77,258 @@ func SelectChartVersion(
 	)
 }
 
+// DownloadChartOptions specifies options for DownloadChart.
+type DownloadChartOptions struct {
+	// Verify indicates whether the downloaded chart's provenance should be
+	// verified against an accompanying .prov file before DownloadChart
+	// returns. Keyring must be set when Verify is true.
+	Verify bool
+	// Keyring is the path to a PGP keyring used to verify the downloaded
+	// chart's provenance. It is required when Verify is true and ignored
+	// otherwise.
+	Keyring string
+}
+
+// DownloadChart downloads the specified version of the chart found in the
+// repository specified by repoURL and returns the path to the downloaded
+// chart archive on disk. The repository can be either a classic chart
+// repository (using HTTP/S) or a repository within an OCI registry, per the
+// same rules as SelectChartVersion. Provided credentials may be nil for
+// public repositories, but must be non-nil for private repositories. If
+// opts.Verify is true, the chart's provenance is verified against
+// opts.Keyring before DownloadChart returns; if no provenance file is found,
+// a non-nil error is returned. If the provenance file fails verification,
+// the downloaded chart archive is removed and a non-nil error is returned.
+func DownloadChart(
+	ctx context.Context,
+	repoURL string,
+	chart string,
+	version string,
+	creds *Credentials,
+	opts DownloadChartOptions,
+) (chartPath string, err error) {
+	if !strings.HasPrefix(repoURL, "http://") &&
+		!strings.HasPrefix(repoURL, "https://") &&
+		!strings.HasPrefix(repoURL, "oci://") {
+		return "", errors.Errorf("repository URL %q is invalid", repoURL)
+	}
+
+	workDir, err := os.MkdirTemp("", "kargo-chart-")
+	if err != nil {
+		return "", errors.Wrap(err, "error creating temporary chart download directory")
+	}
+	// Clean up the working directory on any non-success return, including
+	// download failures and verification failures, so that repeated
+	// reconciliations of a Stage subscription don't leak a temporary
+	// directory per attempt.
+	defer func() {
+		if err != nil {
+			_ = os.RemoveAll(workDir)
+		}
+	}()
+
+	var provPath string
+	if strings.HasPrefix(repoURL, "http://") || strings.HasPrefix(repoURL, "https://") {
+		chartPath, provPath, err =
+			downloadChartFromClassicRepo(workDir, repoURL, chart, version, creds)
+	} else {
+		chartPath, provPath, err =
+			downloadChartFromOCIRepo(ctx, workDir, repoURL, chart, version, creds)
+	}
+	if err != nil {
+		return "", errors.Wrapf(
+			err,
+			"error downloading version %q of chart %q from repository %q",
+			version,
+			chart,
+			repoURL,
+		)
+	}
+
+	if opts.Verify {
+		if provPath == "" {
+			return "", errors.Errorf(
+				"no provenance file found for version %q of chart %q from repository %q",
+				version,
+				chart,
+				repoURL,
+			)
+		}
+		if err = VerifyChart(chartPath, provPath, opts.Keyring); err != nil {
+			return "", errors.Wrapf(
+				err,
+				"error verifying provenance of version %q of chart %q from repository %q",
+				version,
+				chart,
+				repoURL,
+			)
+		}
+	}
+
+	return chartPath, nil
+}
+
+// downloadChartFromClassicRepo downloads the specified version of the
+// specified chart from the classic (HTTP/S) chart repository specified by
+// repoURL into dir, returning the paths to the downloaded chart archive and,
+// if present, its companion provenance file. The provided repoURL MUST begin
+// with protocol http:// or https://.
+func downloadChartFromClassicRepo(
+	dir string,
+	repoURL string,
+	chart string,
+	version string,
+	creds *Credentials,
+) (string, string, error) {
+	chartURL := fmt.Sprintf(
+		"%s/%s-%s.tgz",
+		strings.TrimSuffix(repoURL, "/"),
+		chart,
+		version,
+	)
+	chartPath := filepath.Join(dir, fmt.Sprintf("%s-%s.tgz", chart, version))
+	if err := downloadFile(chartURL, chartPath, repoURL, creds); err != nil {
+		return "", "", errors.Wrapf(
+			err,
+			"error downloading chart archive from %q",
+			chartURL,
+		)
+	}
+	provURL := chartURL + ".prov"
+	provPath := chartPath + ".prov"
+	if err := downloadFile(provURL, provPath, repoURL, creds); err != nil {
+		// Provenance files are optional. If one isn't found, callers that
+		// requested verification will fail with a clearer error; callers
+		// that didn't will simply proceed without one.
+		return chartPath, "", nil
+	}
+	return chartPath, provPath, nil
+}
+
+// downloadFile downloads the file at url to path. creds, if non-nil, are
+// sent if url shares a host with repoURL, or unconditionally when
+// creds.PassCredentialsAll is true — mirroring Helm's own
+// `--pass-credentials` behavior for dependency charts hosted elsewhere.
+func downloadFile(url, path, repoURL string, creds *Credentials) error {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return errors.Wrapf(err, "error preparing HTTP/S request to %q", url)
+	}
+	if creds != nil && (creds.PassCredentialsAll || sameHost(url, repoURL)) {
+		setAuth(req, creds)
+	}
+	client, err := newHTTPClient(creds)
+	if err != nil {
+		return errors.Wrapf(err, "error building HTTP/S client for %q", url)
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "error requesting %q", url)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return errors.Errorf(
+			"received unexpected HTTP %d when requesting %q",
+			res.StatusCode,
+			url,
+		)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return errors.Wrapf(err, "error creating file %q", path)
+	}
+	defer file.Close()
+	if _, err = io.Copy(file, res.Body); err != nil {
+		return errors.Wrapf(err, "error writing file %q", path)
+	}
+	return nil
+}
+
+// downloadChartFromOCIRepo downloads the specified version of the chart found
+// in the OCI repository specified by repoURL into dir, returning the path to
+// the downloaded chart archive and, if present, its companion provenance
+// file. OCI registries do not universally support provenance files, so the
+// second return value may be empty even when no error occurs.
+func downloadChartFromOCIRepo(
+	ctx context.Context,
+	dir string,
+	repoURL string,
+	chart string,
+	version string,
+	creds *Credentials,
+) (string, string, error) {
+	ref, err := registry.ParseReference(
+		strings.TrimPrefix(repoURL, "oci://") + ":" + version,
+	)
+	if err != nil {
+		return "", "", errors.Wrapf(err, "error parsing repository URL %q", repoURL)
+	}
+	rep := &remote.Repository{
+		Reference: ref,
+		Client: &auth.Client{
+			Credential: func(context.Context, string) (auth.Credential, error) {
+				if creds != nil {
+					return auth.Credential{
+						Username: creds.Username,
+						Password: creds.Password,
+					}, nil
+				}
+				return auth.Credential{}, nil
+			},
+		},
+	}
+	_, rc, err := rep.FetchReference(ctx, ref.Reference)
+	if err != nil {
+		return "", "", errors.Wrapf(
+			err,
+			"error fetching chart artifact %q",
+			ref.Reference,
+		)
+	}
+	defer rc.Close()
+	chartPath := filepath.Join(dir, fmt.Sprintf("%s-%s.tgz", chart, version))
+	file, err := os.Create(chartPath)
+	if err != nil {
+		return "", "", errors.Wrapf(err, "error creating file %q", chartPath)
+	}
+	defer file.Close()
+	if _, err = io.Copy(file, rc); err != nil {
+		return "", "", errors.Wrapf(err, "error writing file %q", chartPath)
+	}
+	// OCI registries have no standard convention for attaching a companion
+	// .prov file, so none is returned here.
+	return chartPath, "", nil
+}
+
+// sameHost returns true if rawURLA and rawURLB share the same host. If
+// either fails to parse as a URL, sameHost conservatively returns false.
+func sameHost(rawURLA, rawURLB string) bool {
+	a, err := url.Parse(rawURLA)
+	if err != nil {
+		return false
+	}
+	b, err := url.Parse(rawURLB)
+	if err != nil {
+		return false
+	}
+	return a.Host == b.Host
+}
+
 // getChartVersionsFromClassicRepo connects to the classic (HTTP/S) chart
 // repository specified by repoURL and retrieves all available versions of the
 // specified chart. The provided repoURL MUST begin with protocol http:// or
 // https://. Provided credentials may be nil for public repositories, but must
-// be non-nil for private repositories.
+// be non-nil for private repositories. If cache is non-nil and already holds
+// an entry for repoURL, the request is sent with If-None-Match and
+// If-Modified-Since headers derived from that entry; an HTTP 304 response is
+// then treated as a cache hit and the index is not re-parsed. On any other
+// successful response, cache is updated with the new validators and parsed
+// entries.
 func getChartVersionsFromClassicRepo(
 	repoURL string,
 	chart string,
 	creds *Credentials,
+	cache IndexCache,
 ) ([]string, error) {
 	indexURL := fmt.Sprintf("%s/index.yaml", strings.TrimSuffix(repoURL, "/"))
 	req, err := http.NewRequest(http.MethodGet, indexURL, nil)
@@ -85,14 +336,50 @@ func getChartVersionsFromClassicRepo(
 		return nil,
 			errors.Wrapf(err, "error preparing HTTP/S request to %q", indexURL)
 	}
-	if creds != nil {
-		req.SetBasicAuth(creds.Username, creds.Password)
+	setAuth(req, creds)
+
+	var cached *CachedIndex
+	if cache != nil {
+		if ci, ok := cache.Get(repoURL); ok {
+			cached = ci
+			if cached.ETag != "" {
+				req.Header.Set("If-None-Match", cached.ETag)
+			}
+			if cached.LastModified != "" {
+				req.Header.Set("If-Modified-Since", cached.LastModified)
+			}
+		}
+	}
+
+	client, err := newHTTPClient(creds)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error building HTTP/S client for %q", indexURL)
 	}
-	res, err := http.DefaultClient.Do(req)
+	res, err := client.Do(req)
 	if err != nil {
 		return nil,
 			errors.Wrapf(err, "error querying repository index at %q", indexURL)
 	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotModified {
+		if cached == nil {
+			return nil, errors.Errorf(
+				"received HTTP 304 from %q but no cached index is available",
+				indexURL,
+			)
+		}
+		entries, ok := cached.Entries[chart]
+		if !ok {
+			return nil, errors.Errorf(
+				"no versions of chart %q found in cached repository index from %q",
+				chart,
+				indexURL,
+			)
+		}
+		return entries, nil
+	}
+
 	if res.StatusCode != http.StatusOK {
 		return nil,
 			errors.Errorf(
@@ -101,7 +388,6 @@ func getChartVersionsFromClassicRepo(
 				indexURL,
 			)
 	}
-	defer res.Body.Close()
 	resBodyBytes, err := io.ReadAll(res.Body)
 	if err != nil {
 		return nil,
@@ -116,7 +402,25 @@ func getChartVersionsFromClassicRepo(
 		return nil,
 			errors.Wrapf(err, "error unmarshaling repository index from %q", indexURL)
 	}
-	entries, ok := index.Entries[chart]
+
+	allEntries := make(map[string][]string, len(index.Entries))
+	for chartName, chartEntries := range index.Entries {
+		chartVersions := make([]string, len(chartEntries))
+		for i, entry := range chartEntries {
+			chartVersions[i] = entry.Version
+		}
+		allEntries[chartName] = chartVersions
+	}
+
+	if cache != nil {
+		cache.Put(repoURL, &CachedIndex{
+			ETag:         res.Header.Get("ETag"),
+			LastModified: res.Header.Get("Last-Modified"),
+			Entries:      allEntries,
+		})
+	}
+
+	versions, ok := allEntries[chart]
 	if !ok {
 		return nil, errors.Errorf(
 			"no versions of chart %q found in repository index from %q",
@@ -124,21 +428,47 @@ func getChartVersionsFromClassicRepo(
 			indexURL,
 		)
 	}
-	versions := make([]string, len(entries))
-	for i, entry := range entries {
-		versions[i] = entry.Version
-	}
 	return versions, nil
 }
 
+// helmConfigMediaType is the media type of the config object within the OCI
+// manifest of an artifact published via `helm push`. It is used to
+// distinguish chart versions from other artifacts (e.g. cosign signatures,
+// SBOMs) that may be co-located in the same OCI repository.
+const helmConfigMediaType = "application/vnd.cncf.helm.config.v1+json"
+
+// nonChartTagPatterns matches tags used by artifacts that are never Helm
+// charts, so that getChartVersionsFromOCIRepo can cheaply skip them without
+// having to fetch their manifests.
+var nonChartTagPatterns = []string{"sha256-*", "*.sig", "*.att", "*.sbom"}
+
+// OCIListOptions affects how getChartVersionsFromOCIRepo (and, transitively,
+// SelectChartVersion) discovers chart versions in an OCI repository.
+type OCIListOptions struct {
+	// CheckArtifactType indicates whether each remaining candidate tag
+	// should be confirmed, via an extra manifest fetch, to actually
+	// reference a Helm chart (config media type helmConfigMediaType) before
+	// it is treated as a version. This is more accurate, but costs two
+	// additional round trips per tag (resolving the tag, then fetching its
+	// manifest), so it defaults to false.
+	CheckArtifactType bool
+}
+
 // getChartVersionsFromOCIRepo connects to the OCI repository specified by
-// repoURL and retrieves all available versions of the specified chart. Provided
+// repoURL and retrieves all available versions of the specified chart.
+// Tags that cannot be Helm charts (cosign signatures, SBOMs, and the
+// sha256-* tags used to attach them) are skipped outright, and any
+// remaining tag that does not parse as semver is silently dropped rather
+// than causing getLatestVersion to fail later on. If opts.CheckArtifactType
+// is true, each remaining tag's manifest is additionally fetched to confirm
+// its config media type is that of a Helm chart before it is kept. Provided
 // credentials may be nil for public repositories, but must be non-nil for
 // private repositories.
 func getChartVersionsFromOCIRepo(
 	ctx context.Context,
 	repoURL string,
 	creds *Credentials,
+	opts OCIListOptions,
 ) ([]string, error) {
 	ref, err := registry.ParseReference(strings.TrimPrefix(repoURL, "oci://"))
 	if err != nil {
@@ -159,16 +489,71 @@ func getChartVersionsFromOCIRepo(
 		},
 	}
 	versions := make([]string, 0, rep.TagListPageSize)
+	err = rep.Tags(ctx, func(tags []string) error {
+		for _, tag := range tags {
+			if isNonChartTag(tag) {
+				continue
+			}
+			if _, err := semver.NewVersion(tag); err != nil {
+				// Not a semver tag -- likely not a chart version at all.
+				// Drop it now instead of letting getLatestVersion choke on
+				// it later.
+				continue
+			}
+			if opts.CheckArtifactType {
+				ok, err := isHelmChart(ctx, rep, tag)
+				if err != nil {
+					return errors.Wrapf(err, "error checking artifact type of tag %q", tag)
+				}
+				if !ok {
+					continue
+				}
+			}
+			versions = append(versions, tag)
+		}
+		return nil
+	})
 	return versions, errors.Wrapf(
-		rep.Tags(ctx, func(t []string) error {
-			versions = append(versions, t...)
-			return nil
-		}),
+		err,
 		"error retrieving versions of chart from repository %q",
 		repoURL,
 	)
 }
 
+// isNonChartTag returns true if tag matches one of nonChartTagPatterns and
+// therefore cannot be a Helm chart version.
+func isNonChartTag(tag string) bool {
+	for _, pattern := range nonChartTagPatterns {
+		if ok, _ := filepath.Match(pattern, tag); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// isHelmChart fetches the manifest referenced by tag within rep and reports
+// whether its config object has the media type of a Helm chart.
+func isHelmChart(ctx context.Context, rep *remote.Repository, tag string) (bool, error) {
+	desc, err := rep.Resolve(ctx, tag)
+	if err != nil {
+		return false, errors.Wrapf(err, "error resolving tag %q", tag)
+	}
+	rc, err := rep.Fetch(ctx, desc)
+	if err != nil {
+		return false, errors.Wrapf(err, "error fetching manifest for tag %q", tag)
+	}
+	defer rc.Close()
+	manifest := struct {
+		Config struct {
+			MediaType string `json:"mediaType,omitempty"`
+		} `json:"config,omitempty"`
+	}{}
+	if err = json.NewDecoder(rc).Decode(&manifest); err != nil {
+		return false, errors.Wrapf(err, "error decoding manifest for tag %q", tag)
+	}
+	return manifest.Config.MediaType == helmConfigMediaType, nil
+}
+
 // getLatestVersion returns the semantically greatest version from the versions
 // provided which satisfies the provided constraints. If no constraints are
 // specified (the empty string is passed), the absolute semantically greatest
@@ -197,19 +582,3 @@ func getLatestVersion(versions []string, constraintStr string) (string, error) {
 	}
 	return "", nil
 }
-
-func UpdateChartDependencies(homePath, chartPath string) error {
-	cmd := exec.Command("helm", "dependency", "update", chartPath)
-	homeEnvVar := fmt.Sprintf("HOME=%s", homePath)
-	if cmd.Env == nil {
-		cmd.Env = []string{homeEnvVar}
-	} else {
-		cmd.Env = append(cmd.Env, homeEnvVar)
-	}
-	_, err := libExec.Exec(cmd)
-	return errors.Wrapf(
-		err,
-		"error running `helm dependency update` for chart at %q",
-		chartPath,
-	)
-}
@@ -0,0 +1,76 @@
+package helm
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGetChartVersionsFromClassicRepo_IndexCaching(t *testing.T) {
+	const etag = `"abc123"`
+	const index = `
+entries:
+  mychart:
+    - version: 1.0.0
+    - version: 1.1.0
+`
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		fmt.Fprint(w, index)
+	}))
+	defer server.Close()
+
+	cache := NewMemoryIndexCache(10)
+
+	versions, err := getChartVersionsFromClassicRepo(server.URL, "mychart", nil, cache)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(versions) != 2 {
+		t.Fatalf("expected 2 versions, got %d", len(versions))
+	}
+	if requests != 1 {
+		t.Fatalf("expected 1 request to the repository, got %d", requests)
+	}
+
+	// The second call should send the cached ETag, receive a 304, and still
+	// come back with the chart's versions -- served from cache rather than
+	// a re-parsed index.
+	versions, err = getChartVersionsFromClassicRepo(server.URL, "mychart", nil, cache)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(versions) != 2 {
+		t.Fatalf("expected 2 cached versions, got %d", len(versions))
+	}
+	if requests != 2 {
+		t.Fatalf("expected a second request to be sent, got %d", requests)
+	}
+}
+
+func TestGetChartVersionsFromClassicRepo_NoCache(t *testing.T) {
+	const index = `
+entries:
+  mychart:
+    - version: 1.0.0
+`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, index)
+	}))
+	defer server.Close()
+
+	versions, err := getChartVersionsFromClassicRepo(server.URL, "mychart", nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(versions) != 1 || versions[0] != "1.0.0" {
+		t.Fatalf("unexpected versions: %v", versions)
+	}
+}
@@ -0,0 +1,74 @@
+package helm
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// newHTTPClient builds an *http.Client configured from creds, suitable for
+// talking to a classic (HTTP/S) chart repository. If creds is nil, or
+// specifies none of CAFile, CertFile, KeyFile, or InsecureSkipTLSVerify, the
+// returned client is equivalent to http.DefaultClient.
+func newHTTPClient(creds *Credentials) (*http.Client, error) {
+	if creds == nil ||
+		(creds.CAFile == "" && creds.CertFile == "" && creds.KeyFile == "" && !creds.InsecureSkipTLSVerify) {
+		return http.DefaultClient, nil
+	}
+
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: creds.InsecureSkipTLSVerify, // nolint: gosec
+	}
+
+	if creds.CAFile != "" {
+		caCert, err := os.ReadFile(creds.CAFile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error reading CA certificate file %q", creds.CAFile)
+		}
+		certPool, err := x509.SystemCertPool()
+		if err != nil || certPool == nil {
+			certPool = x509.NewCertPool()
+		}
+		if !certPool.AppendCertsFromPEM(caCert) {
+			return nil, errors.Errorf("no certificates found in CA certificate file %q", creds.CAFile)
+		}
+		tlsConfig.RootCAs = certPool
+	}
+
+	if creds.CertFile != "" || creds.KeyFile != "" {
+		if creds.CertFile == "" || creds.KeyFile == "" {
+			return nil, errors.New("CertFile and KeyFile must both be specified for mTLS")
+		}
+		cert, err := tls.LoadX509KeyPair(creds.CertFile, creds.KeyFile)
+		if err != nil {
+			return nil, errors.Wrapf(
+				err,
+				"error loading client certificate/key pair from %q and %q",
+				creds.CertFile,
+				creds.KeyFile,
+			)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone() // nolint: forcetypeassert
+	transport.TLSClientConfig = tlsConfig
+
+	return &http.Client{Transport: transport}, nil
+}
+
+// setAuth applies the authentication scheme specified by creds to req. A
+// non-empty BearerToken takes precedence over Username/Password.
+func setAuth(req *http.Request, creds *Credentials) {
+	if creds == nil {
+		return
+	}
+	if creds.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+creds.BearerToken)
+		return
+	}
+	req.SetBasicAuth(creds.Username, creds.Password)
+}
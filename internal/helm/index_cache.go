@@ -0,0 +1,142 @@
+package helm
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// CachedIndex is a cached representation of a classic chart repository's
+// index.yaml, along with the HTTP validators needed to conditionally
+// re-fetch it on a subsequent reconciliation.
+type CachedIndex struct {
+	// ETag is the value of the index's ETag response header, if the
+	// repository sent one.
+	ETag string
+	// LastModified is the value of the index's Last-Modified response
+	// header, if the repository sent one.
+	LastModified string
+	// Entries maps chart name to the versions available for that chart, as
+	// last parsed from the index.
+	Entries map[string][]string
+}
+
+// IndexCache is implemented by types that can cache a classic chart
+// repository's index.yaml across reconciliations, keyed by repository URL.
+// Because Kargo re-resolves chart subscriptions on every reconciliation, an
+// IndexCache lets getChartVersionsFromClassicRepo avoid re-downloading and
+// re-parsing large indexes (e.g. Bitnami's, which is tens of megabytes) when
+// the repository reports the index hasn't changed.
+type IndexCache interface {
+	// Get returns the CachedIndex for repoURL and true, or nil and false if
+	// nothing is cached for it.
+	Get(repoURL string) (*CachedIndex, bool)
+	// Put stores index as the CachedIndex for repoURL, replacing any
+	// previously cached entry.
+	Put(repoURL string, index *CachedIndex)
+}
+
+// memoryIndexCache is an in-memory, LRU-evicted IndexCache.
+type memoryIndexCache struct {
+	mu       sync.Mutex
+	capacity int
+	ll       *list.List
+	items    map[string]*list.Element
+}
+
+type memoryIndexCacheEntry struct {
+	repoURL string
+	index   *CachedIndex
+}
+
+// NewMemoryIndexCache returns an IndexCache backed by an in-memory LRU of at
+// most capacity entries. A non-positive capacity falls back to a reasonable
+// default.
+func NewMemoryIndexCache(capacity int) IndexCache {
+	if capacity <= 0 {
+		capacity = 100
+	}
+	return &memoryIndexCache{
+		capacity: capacity,
+		ll:       list.New(),
+		items:    map[string]*list.Element{},
+	}
+}
+
+func (c *memoryIndexCache) Get(repoURL string) (*CachedIndex, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.items[repoURL]
+	if !ok {
+		return nil, false
+	}
+	c.ll.MoveToFront(el)
+	return el.Value.(*memoryIndexCacheEntry).index, true // nolint: forcetypeassert
+}
+
+func (c *memoryIndexCache) Put(repoURL string, index *CachedIndex) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[repoURL]; ok {
+		c.ll.MoveToFront(el)
+		el.Value.(*memoryIndexCacheEntry).index = index // nolint: forcetypeassert
+		return
+	}
+	el := c.ll.PushFront(&memoryIndexCacheEntry{repoURL: repoURL, index: index})
+	c.items[repoURL] = el
+	if c.ll.Len() > c.capacity {
+		oldest := c.ll.Back()
+		if oldest != nil {
+			c.ll.Remove(oldest)
+			delete(c.items, oldest.Value.(*memoryIndexCacheEntry).repoURL) // nolint: forcetypeassert
+		}
+	}
+}
+
+// diskIndexCache is a disk-backed IndexCache rooted at a configurable path.
+// Each cached index is stored as a single JSON file named after the SHA256
+// digest of its repository URL.
+type diskIndexCache struct {
+	rootPath string
+}
+
+// NewDiskIndexCache returns an IndexCache that persists cached indexes as
+// files beneath rootPath, which is created on first use if it does not
+// already exist.
+func NewDiskIndexCache(rootPath string) IndexCache {
+	return &diskIndexCache{rootPath: rootPath}
+}
+
+func (c *diskIndexCache) Get(repoURL string) (*CachedIndex, bool) {
+	data, err := os.ReadFile(c.pathFor(repoURL))
+	if err != nil {
+		return nil, false
+	}
+	index := &CachedIndex{}
+	if err = json.Unmarshal(data, index); err != nil {
+		return nil, false
+	}
+	return index, true
+}
+
+func (c *diskIndexCache) Put(repoURL string, index *CachedIndex) {
+	data, err := json.Marshal(index)
+	if err != nil {
+		return
+	}
+	path := c.pathFor(repoURL)
+	if err = os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return
+	}
+	_ = os.WriteFile(path, data, 0o644)
+}
+
+// pathFor returns the path at which the cached index for repoURL is stored.
+func (c *diskIndexCache) pathFor(repoURL string) string {
+	sum := sha256.Sum256([]byte(repoURL))
+	return filepath.Join(c.rootPath, hex.EncodeToString(sum[:])+".json")
+}
@@ -0,0 +1,33 @@
+package helm
+
+import (
+	"github.com/pkg/errors"
+	"helm.sh/helm/v3/pkg/provenance"
+)
+
+// VerifyChart verifies the authenticity of the chart archive found at
+// chartPath using the companion provenance file at provFile. The clearsigned
+// PGP signature embedded in provFile is checked against the keys in keyring,
+// and the SHA256 digest recorded in provFile is compared against the actual
+// digest of the file at chartPath. A non-nil error is returned if the
+// provenance file cannot be read, the signature cannot be verified against
+// keyring, or the digests do not match. This mirrors the checks performed by
+// Helm's own `helm verify` command and downloader.VerifyAlways.
+func VerifyChart(chartPath, provFile, keyring string) error {
+	if keyring == "" {
+		return errors.New("a keyring is required to verify chart provenance")
+	}
+	sig, err := provenance.NewFromKeyring(keyring, "")
+	if err != nil {
+		return errors.Wrapf(err, "error loading keyring %q", keyring)
+	}
+	if _, err = sig.Verify(chartPath, provFile); err != nil {
+		return errors.Wrapf(
+			err,
+			"error verifying chart %q against provenance file %q",
+			chartPath,
+			provFile,
+		)
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package helm
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestVerifyChart(t *testing.T) {
+	testCases := []struct {
+		name      string
+		chartPath string
+		provFile  string
+		keyring   string
+		assertErr func(*testing.T, error)
+	}{
+		{
+			name:      "no keyring provided",
+			chartPath: "irrelevant.tgz",
+			provFile:  "irrelevant.tgz.prov",
+			keyring:   "",
+			assertErr: func(t *testing.T, err error) {
+				if err == nil {
+					t.Fatal("expected an error, got nil")
+				}
+			},
+		},
+		{
+			name:      "keyring does not exist",
+			chartPath: "irrelevant.tgz",
+			provFile:  "irrelevant.tgz.prov",
+			keyring:   filepath.Join(t.TempDir(), "no-such-keyring.gpg"),
+			assertErr: func(t *testing.T, err error) {
+				if err == nil {
+					t.Fatal("expected an error, got nil")
+				}
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := VerifyChart(testCase.chartPath, testCase.provFile, testCase.keyring)
+			testCase.assertErr(t, err)
+		})
+	}
+}